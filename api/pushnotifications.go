@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/status-im/status-go/pushnotifier"
+)
+
+// pushTransports holds the pushnotifier.Transport registered for each
+// platform by ConfigurePushNotifications, replacing the legacy go-fcm
+// client NotifyUsers used to hold directly.
+var (
+	pushTransportsMu sync.Mutex
+	pushTransports   = map[string]pushnotifier.Transport{}
+)
+
+// ConfigurePushNotifications registers transport as the one NotifyUsers
+// dispatches to for platform (e.g. "android" or "ios").
+func ConfigurePushNotifications(platform string, transport pushnotifier.Transport) {
+	pushTransportsMu.Lock()
+	defer pushTransportsMu.Unlock()
+	pushTransports[platform] = transport
+}
+
+// NotifyUsers sends a push notification with the given title/message to
+// tokens, via the pushnotifier.Transport registered for platform.
+func (b *StatusBackend) NotifyUsers(ctx context.Context, platform, title, message string, data map[string]string, tokens []string) ([]pushnotifier.Result, error) {
+	pushTransportsMu.Lock()
+	transport, ok := pushTransports[platform]
+	pushTransportsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no push notification transport configured for platform %q", platform)
+	}
+
+	return transport.Send(ctx, tokens, pushnotifier.Message{
+		Title: title,
+		Body:  message,
+		Data:  data,
+	})
+}
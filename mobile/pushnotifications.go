@@ -0,0 +1,61 @@
+package status
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/status-im/status-go/api"
+	"github.com/status-im/status-go/pushnotifier"
+)
+
+// pushNotificationsConfig is the JSON shape ConfigurePushNotifications
+// accepts. Either platform may be omitted if the client doesn't support it.
+type pushNotificationsConfig struct {
+	Android *struct {
+		ProjectID          string `json:"projectId"`
+		ServiceAccountJSON string `json:"serviceAccountJson"`
+	} `json:"android"`
+	IOS *struct {
+		KeyID         string `json:"keyId"`
+		TeamID        string `json:"teamId"`
+		BundleID      string `json:"bundleId"`
+		SigningKeyPEM string `json:"signingKeyPem"`
+	} `json:"ios"`
+}
+
+// ConfigurePushNotifications builds the FCM v1 and/or APNs transports
+// NotifyUsers dispatches to, replacing the legacy go-fcm client that only
+// spoke the (now sunset) FCM legacy HTTP API and had no APNs support at
+// all, and registers them with statusBackend under the platform keys
+// "android" and "ios" - what NotifyUsers expects in its payload's
+// "platform" field.
+func ConfigurePushNotifications(configJSON string) string {
+	var cfg pushNotificationsConfig
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	if cfg.Android != nil {
+		transport, err := pushnotifier.NewFCMv1Transport(cfg.Android.ProjectID, []byte(cfg.Android.ServiceAccountJSON))
+		if err != nil {
+			return makeJSONResponse(err)
+		}
+		api.ConfigurePushNotifications("android", transport)
+	}
+
+	if cfg.IOS != nil {
+		block, _ := pem.Decode([]byte(cfg.IOS.SigningKeyPEM))
+		if block == nil {
+			return makeJSONResponse(fmt.Errorf("invalid APNs signing key PEM"))
+		}
+		signingKey, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return makeJSONResponse(fmt.Errorf("failed to parse APNs signing key: %v", err))
+		}
+		api.ConfigurePushNotifications("ios", pushnotifier.NewAPNsTransport(cfg.IOS.KeyID, cfg.IOS.TeamID, cfg.IOS.BundleID, signingKey))
+	}
+
+	return makeJSONResponse(nil)
+}
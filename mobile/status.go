@@ -1,17 +1,18 @@
 package status
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"unsafe"
 
-	"github.com/NaySoftware/go-fcm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/status-im/status-go/api"
 	"github.com/status-im/status-go/logutils"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/profiling"
+	"github.com/status-im/status-go/pushnotifier"
 	"github.com/status-im/status-go/services/personal"
 	"github.com/status-im/status-go/signal"
 	"github.com/status-im/status-go/transactions"
@@ -56,6 +57,7 @@ func StartNode(configJSON string) string {
 
 //StopNode - stop status node
 func StopNode() string {
+	subscriptions.stopAll()
 	api.RunAsync(statusBackend.StopNode)
 	return makeJSONResponse(nil)
 }
@@ -193,6 +195,7 @@ func Login(address, password string) string {
 
 //Logout is equivalent to clearing whisper identities
 func Logout() string {
+	subscriptions.stopAll()
 	err := statusBackend.Logout()
 	return makeJSONResponse(err)
 }
@@ -269,21 +272,40 @@ func makeJSONResponse(err error) string {
 	return string(outBytes)
 }
 
-// NotifyUsers sends push notifications by given tokens.
+// notifyPayload is the JSON payload NotifyUsers accepts: the notification's
+// title, any free-form data to carry alongside message, and which platform
+// (and therefore which registered pushnotifier.Transport, see
+// ConfigurePushNotifications) the tokens belong to.
+type notifyPayload struct {
+	Platform string            `json:"platform"`
+	Title    string            `json:"title"`
+	Data     map[string]string `json:"data"`
+}
+
+// NotifyResults is NotifyUsers' response: one pushnotifier.Result per token,
+// so the mobile side can prune tokens FCM/APNs reported as dead instead of
+// just learning that the batch as a whole failed.
+type NotifyResults struct {
+	Results []pushnotifier.Result `json:"results"`
+	Error   string                `json:"error"`
+}
+
+// NotifyUsers sends a push notification with the given message to tokens,
+// via the pushnotifier.Transport registered for payloadJSON's "platform".
 func NotifyUsers(message, payloadJSON, tokensArray string) (outCBytes string) {
 	var (
-		err      error
-		outBytes []byte
+		err     error
+		results []pushnotifier.Result
 	)
 	errString := ""
 
 	defer func() {
-		out := NotifyResult{
-			Status: err == nil,
-			Error:  errString,
+		out := NotifyResults{
+			Results: results,
+			Error:   errString,
 		}
 
-		outBytes, err = json.Marshal(out)
+		outBytes, err := json.Marshal(out)
 		if err != nil {
 			logger.Error("failed to marshal Notify output", "error", err)
 			outCBytes = makeJSONResponse(err)
@@ -299,14 +321,14 @@ func NotifyUsers(message, payloadJSON, tokensArray string) (outCBytes string) {
 		return
 	}
 
-	var payload fcm.NotificationPayload
+	var payload notifyPayload
 	err = json.Unmarshal([]byte(payloadJSON), &payload)
 	if err != nil {
 		errString = err.Error()
 		return
 	}
 
-	err = statusBackend.NotifyUsers(message, payload, tokens...)
+	results, err = statusBackend.NotifyUsers(context.Background(), payload.Platform, payload.Title, message, payload.Data, tokens)
 	if err != nil {
 		errString = err.Error()
 		return
@@ -0,0 +1,181 @@
+package status
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/signal"
+)
+
+// subscriptionRequest is the shape SubscribeSignal expects as JSON: the
+// eth_subscribe-style method to subscribe to (e.g. "newHeads", "logs",
+// "newPendingTransactions") plus any method-specific parameters.
+type subscriptionRequest struct {
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// subscription is a single live subscription, forwarding every notification
+// it receives to the mobile signal callback until it is closed or the node
+// stops.
+type subscription struct {
+	client *gethrpc.ClientSubscription
+	cancel context.CancelFunc
+}
+
+func (s *subscription) close() {
+	s.client.Unsubscribe()
+	s.cancel()
+}
+
+// subscriptionManager tracks every subscription opened via SubscribeSignal,
+// keyed by the id handed back to the mobile client, so they can all be torn
+// down together on Logout/StopNode.
+type subscriptionManager struct {
+	mu   sync.Mutex
+	subs map[string]*subscription
+}
+
+var subscriptions = &subscriptionManager{subs: map[string]*subscription{}}
+
+func (m *subscriptionManager) add(id string, sub *subscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[id] = sub
+}
+
+func (m *subscriptionManager) remove(id string) (*subscription, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.subs[id]
+	delete(m.subs, id)
+	return sub, ok
+}
+
+// stopAll tears down every active subscription; called on Logout/StopNode so
+// that no subscription outlives the node it was opened against.
+func (m *subscriptionManager) stopAll() {
+	m.mu.Lock()
+	subs := m.subs
+	m.subs = map[string]*subscription{}
+	m.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// SubscribeSignal opens one or more eth_subscribe-style subscriptions
+// against the running node's RPC client, described by subscriptionsJSON (a
+// subscriptionRequest, or a JSON array of them so a React Native client can
+// register several subscriptions in a single JNI/cgo call). Every
+// notification received on any of them is delivered to the mobile signal
+// callback as an EventSubscriptionData envelope carrying its subscription
+// id, until UnsubscribeSignal is called or the node stops.
+//
+// It returns the id assigned to the new subscription, or an array of ids
+// when more than one subscription was opened (a batch of one yields a bare
+// id), to be passed to UnsubscribeSignal later.
+func SubscribeSignal(subscriptionsJSON string) string {
+	reqs, err := parseSubscriptionRequests(subscriptionsJSON)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	client := statusBackend.StatusNode().RPCClient()
+	if client == nil {
+		return makeJSONResponse(fmt.Errorf("node is not running"))
+	}
+
+	ids := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		id, err := openSubscription(client, req)
+		if err != nil {
+			return makeJSONResponse(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 1 {
+		return prepareJSONResponse(ids[0], nil)
+	}
+	return prepareJSONResponse(ids, nil)
+}
+
+// UnsubscribeSignal closes the subscription previously opened with id by
+// SubscribeSignal.
+func UnsubscribeSignal(id string) string {
+	sub, ok := subscriptions.remove(id)
+	if !ok {
+		return makeJSONResponse(fmt.Errorf("subscription %s not found", id))
+	}
+
+	sub.close()
+	return makeJSONResponse(nil)
+}
+
+func parseSubscriptionRequests(subscriptionsJSON string) ([]subscriptionRequest, error) {
+	var reqs []subscriptionRequest
+	if err := json.Unmarshal([]byte(subscriptionsJSON), &reqs); err == nil {
+		return reqs, nil
+	}
+
+	var req subscriptionRequest
+	if err := json.Unmarshal([]byte(subscriptionsJSON), &req); err != nil {
+		return nil, err
+	}
+	return []subscriptionRequest{req}, nil
+}
+
+func openSubscription(client *rpc.Client, req subscriptionRequest) (string, error) {
+	ch := make(chan json.RawMessage)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	clientSub, err := client.EthSubscribe(ctx, ch, req.Method, req.Params...)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	subscriptions.add(id, &subscription{client: clientSub, cancel: cancel})
+
+	go func() {
+		for {
+			select {
+			case result, ok := <-ch:
+				if !ok {
+					return
+				}
+				signal.SendSubscriptionData(id, result)
+			case err := <-clientSub.Err():
+				if err != nil {
+					logger.Error("subscription ended", "id", id, "error", err)
+				}
+				subscriptions.remove(id)
+				return
+			}
+		}
+	}()
+
+	return id, nil
+}
+
+func newSubscriptionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
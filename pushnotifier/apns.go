@@ -0,0 +1,146 @@
+package pushnotifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	apnsProductionHost = "https://api.push.apple.com"
+	apnsTokenLifetime  = 50 * time.Minute
+)
+
+// APNsTransport sends push notifications to iOS devices over APNs' HTTP/2
+// API (Go's net/http negotiates HTTP/2 automatically over TLS), authenticating
+// with a provider token - an ES256-signed JWT rotated well before APNs'
+// one-hour expiry, rather than a long-lived certificate.
+type APNsTransport struct {
+	host       string
+	keyID      string
+	teamID     string
+	bundleID   string
+	signingKey *ecdsa.PrivateKey
+	client     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	tokenTime time.Time
+}
+
+// NewAPNsTransport builds a transport signing provider tokens with
+// signingKey (the private key backing the .p8 key Apple issues for a given
+// keyID/teamID pair), pushing to bundleID.
+func NewAPNsTransport(keyID, teamID, bundleID string, signingKey *ecdsa.PrivateKey) *APNsTransport {
+	return &APNsTransport{
+		host:       apnsProductionHost,
+		keyID:      keyID,
+		teamID:     teamID,
+		bundleID:   bundleID,
+		signingKey: signingKey,
+		client:     &http.Client{},
+	}
+}
+
+type apnsPayload struct {
+	Aps  apnsAps           `json:"aps"`
+	Data map[string]string `json:"data,omitempty"`
+}
+
+type apnsAps struct {
+	Alert apnsAlert `json:"alert"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Send implements Transport, issuing one HTTP/2 request per token as APNs
+// has no batch endpoint.
+func (t *APNsTransport) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	results := make([]Result, len(tokens))
+	for i, token := range tokens {
+		results[i] = t.sendOne(ctx, token, msg)
+	}
+	return results, nil
+}
+
+func (t *APNsTransport) sendOne(ctx context.Context, token string, msg Message) Result {
+	body, err := json.Marshal(apnsPayload{
+		Aps:  apnsAps{Alert: apnsAlert{Title: msg.Title, Body: msg.Body}},
+		Data: msg.Data,
+	})
+	if err != nil {
+		return Result{Token: token, Error: err.Error()}
+	}
+
+	providerToken, err := t.providerToken()
+	if err != nil {
+		return Result{Token: token, Error: err.Error()}
+	}
+
+	url := fmt.Sprintf("%s/3/device/%s", t.host, token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{Token: token, Error: err.Error()}
+	}
+	req.Header.Set("apns-topic", t.bundleID)
+	req.Header.Set("authorization", "bearer "+providerToken)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return Result{Token: token, Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return Result{Token: token, Success: true}
+	}
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	return Result{Token: token, Error: fmt.Sprintf("apns: status %d: %s", resp.StatusCode, respBody)}
+}
+
+// providerToken returns the current ES256 provider token, signing a new one
+// if the cached token is missing or older than apnsTokenLifetime.
+func (t *APNsTransport) providerToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Since(t.tokenTime) < apnsTokenLifetime {
+		return t.token, nil
+	}
+
+	header := base64URLEncode([]byte(fmt.Sprintf(`{"alg":"ES256","kid":%q}`, t.keyID)))
+	claims := base64URLEncode([]byte(fmt.Sprintf(`{"iss":%q,"iat":%d}`, t.teamID, time.Now().Unix())))
+	signingInput := header + "." + claims
+
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, t.signingKey, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs provider token: %v", err)
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	t.token = signingInput + "." + base64URLEncode(sig)
+	t.tokenTime = time.Now()
+
+	return t.token, nil
+}
+
+func base64URLEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
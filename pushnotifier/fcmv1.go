@@ -0,0 +1,161 @@
+package pushnotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
+)
+
+const fcmSendURLFormat = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+
+var fcmScopes = []string{"https://www.googleapis.com/auth/firebase.messaging"}
+
+// FCMv1Transport sends push notifications through the FCM HTTP v1 API, the
+// OAuth2-based endpoint that replaced the legacy FCM HTTP API Google is
+// sunsetting.
+type FCMv1Transport struct {
+	projectID string
+	client    *http.Client
+
+	jwtConfig *jwt.Config
+
+	mu       sync.Mutex
+	tokenSrc oauth2.TokenSource
+}
+
+// NewFCMv1Transport builds a transport authenticating as the service account
+// described by serviceAccountJSON (as downloaded from the Firebase console)
+// against project projectID.
+func NewFCMv1Transport(projectID string, serviceAccountJSON []byte) (*FCMv1Transport, error) {
+	jwtConfig, err := google.JWTConfigFromJSON(serviceAccountJSON, fcmScopes...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM service account credentials: %v", err)
+	}
+
+	return &FCMv1Transport{
+		projectID: projectID,
+		client:    http.DefaultClient,
+		jwtConfig: jwtConfig,
+		tokenSrc:  jwtConfig.TokenSource(context.Background()),
+	}, nil
+}
+
+// token returns the transport's current cached OAuth2 token source's token.
+func (t *FCMv1Transport) token() (*oauth2.Token, error) {
+	t.mu.Lock()
+	src := t.tokenSrc
+	t.mu.Unlock()
+
+	return src.Token()
+}
+
+// refreshToken discards the cached token source and mints a fresh one, so
+// the next call to token forces an actual token request against Google
+// rather than replaying the same not-yet-expired token oauth2's reuse cache
+// would otherwise hand back.
+func (t *FCMv1Transport) refreshToken() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokenSrc = t.jwtConfig.TokenSource(context.Background())
+}
+
+type fcmMessageEnvelope struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Send implements Transport. FCM v1 has no batch endpoint, so it issues one
+// request per token, refreshing the OAuth2 token once on a 401 and retrying
+// 429/5xx responses with exponential backoff.
+func (t *FCMv1Transport) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	results := make([]Result, len(tokens))
+	for i, token := range tokens {
+		results[i] = t.sendOne(ctx, token, msg)
+	}
+	return results, nil
+}
+
+const fcmMaxAttempts = 5
+
+func (t *FCMv1Transport) sendOne(ctx context.Context, token string, msg Message) Result {
+	body, err := json.Marshal(fcmMessageEnvelope{Message: fcmMessage{
+		Token:        token,
+		Notification: &fcmNotification{Title: msg.Title, Body: msg.Body},
+		Data:         msg.Data,
+	}})
+	if err != nil {
+		return Result{Token: token, Error: err.Error()}
+	}
+
+	url := fmt.Sprintf(fcmSendURLFormat, t.projectID)
+
+	refreshed := false
+	backoff := 500 * time.Millisecond
+	for attempt := 0; attempt < fcmMaxAttempts; attempt++ {
+		status, respBody, err := t.doRequest(ctx, url, body)
+		if err != nil {
+			return Result{Token: token, Error: err.Error()}
+		}
+
+		switch {
+		case status == http.StatusOK:
+			return Result{Token: token, Success: true}
+		case status == http.StatusUnauthorized && !refreshed:
+			refreshed = true
+			t.refreshToken()
+		case status == http.StatusTooManyRequests || status >= 500:
+			time.Sleep(backoff)
+			backoff *= 2
+		default:
+			return Result{Token: token, Error: fmt.Sprintf("fcm: unexpected status %d: %s", status, respBody)}
+		}
+	}
+
+	return Result{Token: token, Error: "fcm: exhausted retries"}
+}
+
+func (t *FCMv1Transport) doRequest(ctx context.Context, url string, body []byte) (int, string, error) {
+	token, err := t.token()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to obtain FCM access token: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return resp.StatusCode, string(respBody), nil
+}
@@ -0,0 +1,27 @@
+package pushnotifier
+
+import "context"
+
+// Message is a platform-agnostic push notification; each Transport
+// translates it into the wire format its backend (FCM or APNs) expects.
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Result is the outcome of delivering a Message to a single device token.
+type Result struct {
+	Token   string
+	Success bool
+	// Error is the backend-reported reason the send failed for this token.
+	// A reason like FCM's "UNREGISTERED" or APNs' "BadDeviceToken" means the
+	// token is dead and the caller should stop using it.
+	Error string
+}
+
+// Transport delivers msg to every token, returning one Result per token in
+// the same order tokens were given.
+type Transport interface {
+	Send(ctx context.Context, tokens []string, msg Message) ([]Result, error)
+}
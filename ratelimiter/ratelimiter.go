@@ -17,10 +17,42 @@ type Config struct {
 	Interval, Capacity, Quantum uint64
 }
 
+// rate returns the token refill rate, in tokens per second, that c describes.
+func rate(c Config) float64 {
+	return 1e9 * float64(c.Quantum) / float64(c.Interval)
+}
+
 // compare config with existing ratelimited bucket.
 func compare(c Config, bucket *ratelimit.Bucket) bool {
-	return int64(c.Capacity) == bucket.Capacity() &&
-		1e9*float64(c.Quantum)/float64(c.Interval) == bucket.Rate()
+	return int64(c.Capacity) == bucket.Capacity() && rate(c) == bucket.Rate()
+}
+
+// EventType identifies what changed about an id's rate limiter state, as
+// reported to subscribers registered via Watch.
+type EventType string
+
+const (
+	EventTypeTake      EventType = "take"
+	EventTypeBlacklist EventType = "blacklist"
+	EventTypeRefill    EventType = "refill"
+)
+
+// Event is a single state change for one id, delivered by Watch so that
+// upper layers (e.g. Whisper peer management) can log or export metrics
+// without polling Available in a hot loop.
+type Event struct {
+	Type      EventType
+	ID        []byte
+	Available int64
+}
+
+// Snapshot is a point-in-time view of a single id's rate limiter state, as
+// returned by Snapshot for a debug RPC endpoint.
+type Snapshot struct {
+	ID                []byte
+	Capacity          int64
+	Available         int64
+	BlacklistDeadline time.Time
 }
 
 func newBucket(c Config) *ratelimit.Bucket {
@@ -32,6 +64,7 @@ func NewPersisted(db *leveldb.DB, config Config, prefix []byte) *PersistedRateLi
 		db:            db,
 		defaultConfig: config,
 		initialized:   map[string]*ratelimit.Bucket{},
+		subscribers:   map[string][]chan Event{},
 		prefix:        prefix,
 	}
 }
@@ -44,6 +77,9 @@ type PersistedRateLimiter struct {
 
 	mu          sync.Mutex
 	initialized map[string]*ratelimit.Bucket
+
+	subMu       sync.Mutex
+	subscribers map[string][]chan Event
 }
 
 func (r *PersistedRateLimiter) blacklist(id []byte, duration time.Duration) error {
@@ -53,9 +89,97 @@ func (r *PersistedRateLimiter) blacklist(id []byte, duration time.Duration) erro
 	if err := r.db.Put(fkey, buf[:], nil); err != nil {
 		return fmt.Errorf("error blacklisting %x: %v", id, err)
 	}
+	r.publish(id, Event{Type: EventTypeBlacklist, ID: id})
 	return nil
 }
 
+// Watch returns a channel that receives an Event every time id is taken
+// from, blacklisted, or refilled on restore, and a function that stops the
+// subscription. The channel is buffered; a subscriber that falls behind has
+// events dropped for it rather than blocking token accounting. The returned
+// cancel function must be called once the subscriber is done watching -
+// ids in this package churn over a node's lifetime (e.g. Whisper peers
+// connecting and disconnecting), so an uncancelled Watch leaks its channel
+// for good.
+func (r *PersistedRateLimiter) Watch(id []byte) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	r.subMu.Lock()
+	r.subscribers[string(id)] = append(r.subscribers[string(id)], ch)
+	r.subMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			r.unwatch(id, ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+func (r *PersistedRateLimiter) unwatch(id []byte, ch chan Event) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	subs := r.subscribers[string(id)]
+	for i, sub := range subs {
+		if sub == ch {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(r.subscribers, string(id))
+	} else {
+		r.subscribers[string(id)] = subs
+	}
+
+	close(ch)
+}
+
+func (r *PersistedRateLimiter) publish(id []byte, evt Event) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	for _, ch := range r.subscribers[string(id)] {
+		select {
+		case ch <- evt:
+		default:
+			log.Warn("dropping ratelimiter event, subscriber is not keeping up", "id", fmt.Sprintf("%x", id), "type", evt.Type)
+		}
+	}
+}
+
+// Snapshot returns the current capacity, available tokens and blacklist
+// deadline (zero if not blacklisted) for every id currently tracked in
+// memory.
+func (r *PersistedRateLimiter) Snapshot() []Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(r.initialized))
+	for id, bucket := range r.initialized {
+		snapshots = append(snapshots, Snapshot{
+			ID:                []byte(id),
+			Capacity:          bucket.Capacity(),
+			Available:         bucket.Available(),
+			BlacklistDeadline: r.blacklistDeadline([]byte(id)),
+		})
+	}
+
+	return snapshots
+}
+
+func (r *PersistedRateLimiter) blacklistDeadline(id []byte) time.Time {
+	val, err := r.db.Get(db.Key(db.RateLimitBlacklist, r.prefix, id), nil)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(binary.BigEndian.Uint64(val)), 0)
+}
+
 func (r *PersistedRateLimiter) Config() Config {
 	return r.defaultConfig
 }
@@ -101,12 +225,19 @@ func (r *PersistedRateLimiter) Create(id []byte) error {
 	if err == leveldb.ErrNotFound {
 		return nil
 	} else if len(val) != 16 {
-		log.Error("stored value is of unexpected length", "expected", 8, "stored", len(val))
+		log.Error("stored value is of unexpected length", "expected", 16, "stored", len(val))
 		return nil
 	}
-	bucket.TakeAvailable(int64(binary.BigEndian.Uint64(val[:8])))
-	// TODO refill rate limiter due to time difference. e.g. if record was stored at T and C seconds passed since T.
-	// we need to add RATE_PER_SECOND*C to a bucket
+	consumed := int64(binary.BigEndian.Uint64(val[:8]))
+	storedAt := int64(binary.BigEndian.Uint64(val[8:]))
+	if elapsed := time.Now().Unix() - storedAt; elapsed > 0 {
+		consumed -= int64(float64(elapsed) * rate(cfg))
+		if consumed < 0 {
+			consumed = 0
+		}
+	}
+	bucket.TakeAvailable(consumed)
+	r.publish(id, Event{Type: EventTypeRefill, ID: id, Available: bucket.Available()})
 	return nil
 }
 
@@ -145,6 +276,7 @@ func (r *PersistedRateLimiter) TakeAvailable(id []byte, count int64) int64 {
 	if err := r.store(id, bucket); err != nil {
 		log.Error(err.Error())
 	}
+	r.publish(id, Event{Type: EventTypeTake, ID: id, Available: bucket.Available()})
 	return rst
 }
 
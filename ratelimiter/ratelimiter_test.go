@@ -0,0 +1,92 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func newTestLimiter(t *testing.T, config Config) *PersistedRateLimiter {
+	t.Helper()
+
+	ldb, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("failed to open in-memory leveldb: %v", err)
+	}
+	t.Cleanup(func() { ldb.Close() })
+
+	return NewPersisted(ldb, config, []byte("test"))
+}
+
+func TestCreateRestoresConsumedTokensAfterRefill(t *testing.T) {
+	id := []byte("peer-1")
+	config := Config{Interval: uint64(time.Second), Capacity: 10, Quantum: 10}
+
+	r := newTestLimiter(t, config)
+	if err := r.Create(id); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	r.TakeAvailable(id, 10)
+	if avail := r.Available(id); avail != 0 {
+		t.Fatalf("Available = %d, want 0 after taking the full capacity", avail)
+	}
+
+	// Forget the in-memory bucket (as a restart would) but keep the
+	// persisted capacity/timestamp, then re-create after the refill
+	// interval has fully elapsed - the bucket should come back full.
+	delete(r.initialized, string(id))
+	time.Sleep(time.Second)
+
+	if err := r.Create(id); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if avail := r.Available(id); avail != config.Capacity {
+		t.Errorf("Available = %d, want %d after Create restores an already-refilled bucket", avail, config.Capacity)
+	}
+}
+
+func TestCreateRejectsBlacklistedID(t *testing.T) {
+	id := []byte("peer-1")
+	r := newTestLimiter(t, Config{Interval: uint64(time.Second), Capacity: 10, Quantum: 10})
+
+	if err := r.blacklist(id, time.Minute); err != nil {
+		t.Fatalf("blacklist failed: %v", err)
+	}
+	if err := r.Create(id); err == nil {
+		t.Fatal("expected Create to reject a blacklisted id")
+	}
+}
+
+func TestWatchReceivesEventsAndUnwatchStopsThem(t *testing.T) {
+	id := []byte("peer-1")
+	r := newTestLimiter(t, Config{Interval: uint64(time.Second), Capacity: 10, Quantum: 10})
+
+	events, cancel := r.Watch(id)
+
+	r.TakeAvailable(id, 1)
+	select {
+	case evt := <-events:
+		if evt.Type != EventTypeTake {
+			t.Errorf("Type = %v, want %v", evt.Type, EventTypeTake)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a take event")
+	}
+
+	cancel()
+	// Calling cancel a second time must not panic (close of closed channel).
+	cancel()
+
+	if _, open := <-events; open {
+		t.Error("events channel should be closed after cancel")
+	}
+
+	r.subMu.Lock()
+	_, stillSubscribed := r.subscribers[string(id)]
+	r.subMu.Unlock()
+	if stillSubscribed {
+		t.Error("unwatch should have removed id from subscribers once its last channel was cancelled")
+	}
+}
@@ -0,0 +1,16 @@
+package rpc
+
+import (
+	"context"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// EthSubscribe opens a subscription on the upstream node's RPC endpoint via
+// Client's own *gethrpc.Client (the "local" field this package already
+// dials internally), giving callers outside this package a way to reach
+// eth_subscribe-style methods without routing through Call/CallContext,
+// which only speak request/response.
+func (c *Client) EthSubscribe(ctx context.Context, channel interface{}, method string, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	return c.local.EthSubscribe(ctx, channel, method, args...)
+}
@@ -0,0 +1,25 @@
+package signal
+
+import "encoding/json"
+
+// EventSubscriptionData is the envelope type used to push a notification
+// received on a subscription opened via the mobile SubscribeSignal FFI call
+// (new heads, logs, pending transactions, ...) down to the client.
+const EventSubscriptionData = "subscription"
+
+// SubscriptionDataEvent is the event payload of an EventSubscriptionData
+// signal: the id SubscribeSignal returned for the subscription, and the raw
+// notification result exactly as the node emitted it.
+type SubscriptionDataEvent struct {
+	SubscriptionID string          `json:"subscription_id"`
+	Result         json.RawMessage `json:"result"`
+}
+
+// SendSubscriptionData sends a SubscriptionDataEvent signal for a single
+// notification received on subscriptionID.
+func SendSubscriptionData(subscriptionID string, result json.RawMessage) {
+	send(EventSubscriptionData, SubscriptionDataEvent{
+		SubscriptionID: subscriptionID,
+		Result:         result,
+	})
+}
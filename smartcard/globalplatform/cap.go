@@ -0,0 +1,151 @@
+package globalplatform
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// componentOrder lists the standard Java Card CAP components in the order
+// GlobalPlatform requires them to be concatenated into a Load File Data
+// Block. Applet, Export and Descriptor are optional; the rest are mandatory.
+var componentOrder = []string{
+	"Header", "Directory", "Import", "Applet", "Class", "Method",
+	"StaticField", "Export", "ConstantPool", "RefLocation", "Descriptor",
+}
+
+// CAPFile is a parsed Java Card CAP archive, indexed by component name
+// (e.g. "Header", "Import"), holding each component's raw bytes exactly as
+// they appear inside the .cap zip, tag and length included.
+type CAPFile struct {
+	components map[string][]byte
+}
+
+// LoadCAPFile reads a Java Card CAP file, a zip archive with one file per
+// component under a package-specific path (e.g. "javacard/keycard/Header.cap").
+func LoadCAPFile(r io.ReaderAt, size int64) (*CAPFile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CAP file: %v", err)
+	}
+
+	cap := &CAPFile{components: map[string][]byte{}}
+	for _, f := range zr.File {
+		name := componentName(f.Name)
+		if name == "" {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s component: %v", name, err)
+		}
+
+		cap.components[name] = data
+	}
+
+	if _, ok := cap.components["Header"]; !ok {
+		return nil, errors.New("CAP file is missing a Header component")
+	}
+
+	return cap, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// componentName maps a zip entry path to the component name it holds, or ""
+// if the entry isn't one of the standard CAP components.
+func componentName(path string) string {
+	base := path
+	if idx := strings.LastIndex(path, "/"); idx != -1 {
+		base = path[idx+1:]
+	}
+
+	for _, name := range componentOrder {
+		if base == name+".cap" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// LoadFileDataBlock concatenates the CAP components in the order
+// GlobalPlatform requires, producing the byte stream that is sent to the
+// card via chained LOAD commands.
+func (c *CAPFile) LoadFileDataBlock() []byte {
+	var block []byte
+	for _, name := range componentOrder {
+		block = append(block, c.components[name]...)
+	}
+	return block
+}
+
+// PackageAID extracts the package AID from the Header component, per the
+// Java Card Virtual Machine Specification layout: tag(1) + size(2) +
+// magic(4) + minor(1) + major(1) + flags(1), followed by package_info
+// (minor(1) + major(1) + AID_length(1) + AID).
+func (c *CAPFile) PackageAID() ([]byte, error) {
+	header, ok := c.components["Header"]
+	if !ok {
+		return nil, errors.New("CAP file has no Header component")
+	}
+
+	const packageInfoOffset = 1 + 2 + 4 + 1 + 1 + 1
+	if len(header) < packageInfoOffset+3 {
+		return nil, errors.New("Header component is truncated")
+	}
+
+	aidLen := int(header[packageInfoOffset+2])
+	start := packageInfoOffset + 3
+	if len(header) < start+aidLen {
+		return nil, errors.New("Header component AID is truncated")
+	}
+
+	return header[start : start+aidLen], nil
+}
+
+// AppletAIDs extracts the AID of every applet declared in the Applet
+// component, in declaration order. It returns nil if the package declares
+// no applets (a library-only CAP file has no Applet component).
+func (c *CAPFile) AppletAIDs() ([][]byte, error) {
+	applet, ok := c.components["Applet"]
+	if !ok {
+		return nil, nil
+	}
+
+	if len(applet) < 4 {
+		return nil, errors.New("Applet component is truncated")
+	}
+
+	count := int(applet[3])
+	aids := make([][]byte, 0, count)
+	offset := 4
+	for i := 0; i < count; i++ {
+		if offset >= len(applet) {
+			return nil, errors.New("Applet component is truncated")
+		}
+
+		aidLen := int(applet[offset])
+		offset++
+		if offset+aidLen+2 > len(applet) {
+			return nil, errors.New("Applet component is truncated")
+		}
+
+		aids = append(aids, applet[offset:offset+aidLen])
+		offset += aidLen + 2 // skip the install method offset
+	}
+
+	return aids, nil
+}
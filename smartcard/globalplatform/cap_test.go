@@ -0,0 +1,190 @@
+package globalplatform
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// buildCAP builds an in-memory CAP zip archive containing one entry per
+// name/data pair under a fixed package path, for use as test input to
+// LoadCAPFile.
+func buildCAP(t *testing.T, components map[string][]byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range components {
+		w, err := zw.Create("javacard/test/" + name + ".cap")
+		if err != nil {
+			t.Fatalf("failed to create %s entry: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("failed to write %s entry: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func header(pkgAID []byte) []byte {
+	h := []byte{
+		0x01, 0x00, 0x00, // tag, size (unused by PackageAID)
+		0xDE, 0xCA, 0xFF, 0xED, // magic
+		0x01, 0x02, // minor, major
+		0x00,       // flags
+		0x01, 0x02, // package_info minor, major
+		byte(len(pkgAID)), // AID length
+	}
+	return append(h, pkgAID...)
+}
+
+func TestLoadCAPFileRequiresHeader(t *testing.T) {
+	data := buildCAP(t, map[string][]byte{"Import": {0x01}})
+
+	_, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		t.Fatal("expected an error for a CAP file with no Header component")
+	}
+}
+
+func TestPackageAID(t *testing.T) {
+	pkgAID := []byte{0xA0, 0x00, 0x00, 0x00, 0x01}
+	data := buildCAP(t, map[string][]byte{"Header": header(pkgAID)})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	got, err := cap.PackageAID()
+	if err != nil {
+		t.Fatalf("PackageAID failed: %v", err)
+	}
+	if !bytes.Equal(got, pkgAID) {
+		t.Errorf("PackageAID = %x, want %x", got, pkgAID)
+	}
+}
+
+func TestPackageAIDTruncatedHeader(t *testing.T) {
+	data := buildCAP(t, map[string][]byte{"Header": {0x01, 0x02, 0x03}})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	if _, err := cap.PackageAID(); err == nil {
+		t.Fatal("expected an error for a truncated Header component")
+	}
+}
+
+func TestPackageAIDTruncatedAID(t *testing.T) {
+	h := header([]byte{0xA0, 0x00})
+	h = h[:len(h)-1] // claims a 2-byte AID but only has 1
+	data := buildCAP(t, map[string][]byte{"Header": h})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	if _, err := cap.PackageAID(); err == nil {
+		t.Fatal("expected an error when the AID is longer than the remaining header bytes")
+	}
+}
+
+func TestAppletAIDsNoAppletComponent(t *testing.T) {
+	data := buildCAP(t, map[string][]byte{"Header": header([]byte{0xA0})})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	aids, err := cap.AppletAIDs()
+	if err != nil {
+		t.Fatalf("AppletAIDs failed: %v", err)
+	}
+	if aids != nil {
+		t.Errorf("AppletAIDs = %v, want nil for a library-only CAP file", aids)
+	}
+}
+
+func TestAppletAIDs(t *testing.T) {
+	aid1 := []byte{0xA0, 0x00, 0x00, 0x00, 0x01}
+	aid2 := []byte{0xA0, 0x00, 0x00, 0x00, 0x02, 0x03}
+
+	applet := []byte{0x01, 0x00, 0x00, 0x02} // tag, size(2), count
+	applet = append(applet, byte(len(aid1)))
+	applet = append(applet, aid1...)
+	applet = append(applet, 0x00, 0x01) // install method offset
+	applet = append(applet, byte(len(aid2)))
+	applet = append(applet, aid2...)
+	applet = append(applet, 0x00, 0x02) // install method offset
+
+	data := buildCAP(t, map[string][]byte{
+		"Header": header([]byte{0xA0}),
+		"Applet": applet,
+	})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	aids, err := cap.AppletAIDs()
+	if err != nil {
+		t.Fatalf("AppletAIDs failed: %v", err)
+	}
+	if len(aids) != 2 || !bytes.Equal(aids[0], aid1) || !bytes.Equal(aids[1], aid2) {
+		t.Errorf("AppletAIDs = %x, want [%x %x]", aids, aid1, aid2)
+	}
+}
+
+func TestAppletAIDsTruncated(t *testing.T) {
+	// Declares 2 applets but only has data for one.
+	aid1 := []byte{0xA0, 0x00, 0x00, 0x00, 0x01}
+	applet := []byte{0x01, 0x00, 0x00, 0x02}
+	applet = append(applet, byte(len(aid1)))
+	applet = append(applet, aid1...)
+	applet = append(applet, 0x00, 0x01)
+
+	data := buildCAP(t, map[string][]byte{
+		"Header": header([]byte{0xA0}),
+		"Applet": applet,
+	})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	if _, err := cap.AppletAIDs(); err == nil {
+		t.Fatal("expected an error when the Applet component is truncated")
+	}
+}
+
+func TestLoadFileDataBlockOrder(t *testing.T) {
+	data := buildCAP(t, map[string][]byte{
+		"Header": {0x01},
+		"Import": {0x02},
+		"Method": {0x03},
+		"Applet": {0x04},
+		"Class":  {0x05},
+	})
+
+	cap, err := LoadCAPFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadCAPFile failed: %v", err)
+	}
+
+	want := []byte{0x01, 0x02, 0x04, 0x05, 0x03}
+	got := cap.LoadFileDataBlock()
+	if !bytes.Equal(got, want) {
+		t.Errorf("LoadFileDataBlock = %x, want %x", got, want)
+	}
+}
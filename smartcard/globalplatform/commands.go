@@ -15,6 +15,15 @@ const (
 	InsExternalAuthenticate = uint8(0x82)
 	InsGetResponse          = uint8(0xC0)
 	InsDelete               = uint8(0xE4)
+	InsInstall              = uint8(0xE6)
+	InsLoad                 = uint8(0xE8)
+	InsPutKey               = uint8(0xD8)
+
+	P1InstallForLoad    = uint8(0x02)
+	P1InstallForInstall = uint8(0x0C) // install + make selectable
+
+	P1LoadMoreBlocks = uint8(0x00)
+	P1LoadLastBlock  = uint8(0x80)
 
 	Sw1ResponseDataIncomplete = uint8(0x61)
 
@@ -23,7 +32,9 @@ const (
 	SwSecurityConditionNotSatisfied = uint16(0x6982)
 	SwAuthenticationMethodBlocked   = uint16(0x6983)
 
-	tagDeleteAID = byte(0x4F)
+	tagDeleteAID      = byte(0x4F)
+	tagInstallParams  = byte(0xC9)
+	defaultPrivileges = byte(0x00)
 )
 
 func NewCommandSelect(aid []byte) *apdu.Command {
@@ -50,19 +61,18 @@ func NewCommandInitializeUpdate(challenge []byte) *apdu.Command {
 	)
 }
 
-func NewCommandExternalAuthenticate(encKey, cardChallenge, hostChallenge []byte) (*apdu.Command, error) {
-	hostCryptogram, err := calculateHostCryptogram(encKey, cardChallenge, hostChallenge)
-	if err != nil {
-		return nil, err
-	}
-
+// NewCommandExternalAuthenticate builds the EXTERNAL AUTHENTICATE command
+// carrying an already-computed host cryptogram. Use SecureChannel.Open to
+// negotiate the secure channel and compute that cryptogram for the card's
+// chosen SCP version; this constructor just wraps the wire format.
+func NewCommandExternalAuthenticate(hostCryptogram []byte) *apdu.Command {
 	return apdu.NewCommand(
 		ClaMac,
 		InsExternalAuthenticate,
 		uint8(0x01), // C-MAC
 		uint8(0x00),
 		hostCryptogram,
-	), nil
+	)
 }
 
 func NewCommandGetResponse(length uint8) *apdu.Command {
@@ -92,11 +102,90 @@ func NewCommandDelete(aid []byte) *apdu.Command {
 	)
 }
 
-func calculateHostCryptogram(encKey, cardChallenge, hostChallenge []byte) ([]byte, error) {
-	var data []byte
-	data = append(data, cardChallenge...)
-	data = append(data, hostChallenge...)
-	data = crypto.AppendDESPadding(data)
+// NewCommandInstallForLoad builds the INSTALL [for load] command that must
+// precede the LOAD commands carrying a package's Load File Data Block.
+// sdAID is the security domain the package is associated with.
+func NewCommandInstallForLoad(packageAID, sdAID []byte) *apdu.Command {
+	data := appendTLVField(nil, packageAID)
+	data = appendTLVField(data, sdAID)
+	data = appendTLVField(data, nil) // load file data block hash, not verified by the card
+	data = appendTLVField(data, nil) // load parameters
+	data = appendTLVField(data, nil) // load token
+
+	return apdu.NewCommand(
+		ClaGp,
+		InsInstall,
+		P1InstallForLoad,
+		uint8(0x00),
+		data,
+	)
+}
+
+// NewCommandLoad builds one block of a chained LOAD command sequence.
+// blockNum must increment by one for each successive block of a given
+// Load File Data Block, and lastBlock must be true only for the final one.
+func NewCommandLoad(blockNum uint8, lastBlock bool, data []byte) *apdu.Command {
+	p1 := P1LoadMoreBlocks
+	if lastBlock {
+		p1 = P1LoadLastBlock
+	}
+
+	return apdu.NewCommand(
+		ClaGp,
+		InsLoad,
+		p1,
+		blockNum,
+		data,
+	)
+}
+
+// NewCommandInstallForInstall builds the INSTALL [for install] command that
+// instantiates an applet (identified by appletAID, found in the package
+// pkgAID already loaded onto the card) as instanceAID, passing params as its
+// install parameters.
+func NewCommandInstallForInstall(pkgAID, appletAID, instanceAID, params []byte) *apdu.Command {
+	data := appendTLVField(nil, pkgAID)
+	data = appendTLVField(data, appletAID)
+	data = appendTLVField(data, instanceAID)
+	data = appendTLVField(data, []byte{defaultPrivileges})
+	data = appendTLVField(data, append([]byte{tagInstallParams, byte(len(params))}, params...))
+	data = appendTLVField(data, nil) // install token
+
+	return apdu.NewCommand(
+		ClaGp,
+		InsInstall,
+		P1InstallForInstall,
+		uint8(0x00),
+		data,
+	)
+}
+
+// NewCommandPutKey builds a PUT KEY command replacing (or adding, when
+// keyVersion is 0) the key identified by keyID with keyData, 3DES-ECB
+// encrypted under dek as GlobalPlatform requires for key component material
+// in transit. kcv is the key check value the card uses to confirm the key
+// landed correctly.
+func NewCommandPutKey(keyVersion, keyID, keyType uint8, keyData, kcv, dek []byte) (*apdu.Command, error) {
+	encryptedKeyData, err := crypto.TripleDESECBEncrypt(dek, keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{keyVersion, keyType}
+	data = appendTLVField(data, encryptedKeyData)
+	data = appendTLVField(data, kcv)
+
+	return apdu.NewCommand(
+		ClaGp,
+		InsPutKey,
+		keyVersion,
+		keyID,
+		data,
+	), nil
+}
 
-	return crypto.Mac3DES(encKey, data, crypto.NullBytes8)
+// appendTLVField appends field to data prefixed with its own length, the
+// length-value encoding GlobalPlatform uses throughout INSTALL data fields.
+func appendTLVField(data, field []byte) []byte {
+	return append(append(data, byte(len(field))), field...)
 }
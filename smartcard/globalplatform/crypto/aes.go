@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+)
+
+// AESCBCEncrypt encrypts data (which must already be a multiple of the AES
+// block size) under key using AES-CBC with the given iv. SCP03 uses this for
+// C-DECRYPTION, with the IV derived from the session command counter rather
+// than chained across commands.
+func AESCBCEncrypt(key, data, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+
+	return out, nil
+}
+
+// AESECBEncryptBlock encrypts a single AES block under key with no chaining.
+// SCP03 uses this (GPC Amendment D section 6.2.6) to turn the session
+// command counter into the IV for C-DECRYPTION/R-ENCRYPTION - a plain block
+// encryption, not a CMAC, since the counter is never chained across blocks.
+func AESECBEncryptBlock(key, block []byte) ([]byte, error) {
+	if len(block) != aesBlockSize {
+		return nil, errors.New("AESECBEncryptBlock: block must be exactly one AES block")
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, aesBlockSize)
+	c.Encrypt(out, block)
+
+	return out, nil
+}
+
+// AESCBCDecrypt decrypts data (which must already be a multiple of the AES
+// block size) under key using AES-CBC with the given iv, the inverse of
+// AESCBCEncrypt used to unwrap R-ENCRYPTION response data.
+func AESCBCDecrypt(key, data, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	return out, nil
+}
+
+// Pad applies ISO/IEC 7816-4 padding (0x80 followed by zeros) to data so its
+// length becomes a multiple of blockSize.
+func Pad(data []byte, blockSize int) []byte {
+	padded := append([]byte{}, data...)
+	padded = append(padded, 0x80)
+	for len(padded)%blockSize != 0 {
+		padded = append(padded, 0x00)
+	}
+	return padded
+}
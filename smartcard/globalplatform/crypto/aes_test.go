@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestAESECBEncryptBlock checks against the FIPS-197 Appendix B AES-128
+// example, confirming this is a plain block encryption and not (as
+// AESCMAC would produce for a single complete block) CMAC'd.
+func TestAESECBEncryptBlock(t *testing.T) {
+	key := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+	plaintext := mustHex(t, "00112233445566778899aabbccddeeff")
+	want := mustHex(t, "69c4e0d86a7b0430d8cdb78070b4c55a")
+
+	got, err := AESECBEncryptBlock(key, plaintext)
+	if err != nil {
+		t.Fatalf("AESECBEncryptBlock failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("AESECBEncryptBlock = %x, want %x", got, want)
+	}
+
+	// Sanity check that this genuinely differs from AESCMAC of the same
+	// single complete block, which is the bug this helper was added to fix.
+	cmac, err := AESCMAC(key, plaintext)
+	if err != nil {
+		t.Fatalf("AESCMAC failed: %v", err)
+	}
+	if bytes.Equal(got, cmac) {
+		t.Error("AESECBEncryptBlock must not equal AESCMAC of the same block")
+	}
+}
+
+func TestAESECBEncryptBlockRejectsShortBlock(t *testing.T) {
+	key := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+	if _, err := AESECBEncryptBlock(key, key[:8]); err == nil {
+		t.Fatal("expected an error for a block shorter than the AES block size")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex fixture %q: %v", s, err)
+	}
+	return b
+}
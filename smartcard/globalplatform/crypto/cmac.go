@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+const aesBlockSize = 16
+
+var aesCMACRb = []byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x87,
+}
+
+// AESCMAC computes the AES-CMAC (RFC 4493) of data under key. SCP03 uses it
+// in place of the 3DES retail MAC the older secure channel protocols rely on,
+// both to protect APDUs and, via CounterModeKDF, to derive session keys.
+func AESCMAC(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k1, k2 := cmacSubkeys(block)
+
+	n := (len(data) + aesBlockSize - 1) / aesBlockSize
+	complete := n > 0 && len(data)%aesBlockSize == 0
+	if n == 0 {
+		n = 1
+		complete = false
+	}
+
+	last := make([]byte, aesBlockSize)
+	copy(last, data[(n-1)*aesBlockSize:])
+	if complete {
+		xorInto(last, k1)
+	} else {
+		last[len(data)-(n-1)*aesBlockSize] = 0x80
+		xorInto(last, k2)
+	}
+
+	iv := make([]byte, aesBlockSize)
+	if n > 1 {
+		leading := data[:(n-1)*aesBlockSize]
+		out := make([]byte, len(leading))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, leading)
+		copy(iv, out[len(out)-aesBlockSize:])
+	}
+
+	mac := make([]byte, aesBlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(mac, last)
+
+	return mac, nil
+}
+
+// cmacSubkeys derives the K1/K2 subkeys RFC 4493 uses to handle the last,
+// possibly incomplete, message block.
+func cmacSubkeys(block cipher.Block) (k1, k2 []byte) {
+	l := make([]byte, aesBlockSize)
+	block.Encrypt(l, l)
+
+	k1 = leftShiftOne(l)
+	if l[0]&0x80 != 0 {
+		xorInto(k1, aesCMACRb)
+	}
+
+	k2 = leftShiftOne(k1)
+	if k1[0]&0x80 != 0 {
+		xorInto(k2, aesCMACRb)
+	}
+
+	return k1, k2
+}
+
+func leftShiftOne(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = (in[i] << 1) | carry
+		carry = in[i] >> 7
+	}
+	return out
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestAESCMAC checks against the RFC 4493 section 4 AES-128 test vectors,
+// covering the empty message, one complete block and a trailing partial
+// block - the two subkey branches AESCMAC's last-block handling takes.
+func TestAESCMAC(t *testing.T) {
+	key := mustHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"empty", "", "bb1d6929e95937287fa37d129b756746"},
+		{
+			"one complete block",
+			"6bc1bee22e409f96e93d7e117393172a",
+			"070a16b46b4d4144f79bdd9dd04a287c",
+		},
+		{
+			"trailing partial block",
+			"6bc1bee22e409f96e93d7e117393172a" +
+				"ae2d8a571e03ac9c9eb76fac45af8e51" +
+				"30c81c46a35ce411",
+			"dfa66747de9ae63030ca32611497c827",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AESCMAC(key, mustHex(t, tt.message))
+			if err != nil {
+				t.Fatalf("AESCMAC failed: %v", err)
+			}
+			if want := mustHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Errorf("AESCMAC = %x, want %x", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"errors"
+)
+
+// NullBytes8 is the all-zero 8-byte ICV GlobalPlatform starts retail-MAC and
+// SCP02 key derivation chains from.
+var NullBytes8 = make([]byte, 8)
+
+// AppendDESPadding applies GlobalPlatform's DES padding (0x80 followed by
+// zeros) to data so its length becomes a multiple of the DES block size.
+func AppendDESPadding(data []byte) []byte {
+	padded := append([]byte{}, data...)
+	padded = append(padded, 0x80)
+	for len(padded)%8 != 0 {
+		padded = append(padded, 0x00)
+	}
+	return padded
+}
+
+// Mac3DES computes GlobalPlatform's retail MAC (ISO/IEC 9797-1 MAC algorithm
+// 3) over data, which must already be a multiple of the DES block size:
+// single-DES CBC under the first 8 bytes of key for every block but the
+// last, which is triple-DES encrypted instead, chaining from iv.
+func Mac3DES(key, data, iv []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%8 != 0 {
+		return nil, errors.New("Mac3DES: data must be a non-zero multiple of the DES block size")
+	}
+
+	singleDES, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, err
+	}
+	tripleDES, err := des.NewTripleDESCipher(expandDESKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	block := append([]byte{}, iv...)
+	for offset := 0; offset < len(data); offset += 8 {
+		chunk := make([]byte, 8)
+		for i := 0; i < 8; i++ {
+			chunk[i] = data[offset+i] ^ block[i]
+		}
+
+		out := make([]byte, 8)
+		if offset+8 >= len(data) {
+			tripleDES.Encrypt(out, chunk)
+		} else {
+			singleDES.Encrypt(out, chunk)
+		}
+		block = out
+	}
+
+	return block, nil
+}
+
+// TripleDESCBCEncrypt encrypts data (a multiple of 8 bytes) under key using
+// 3DES in CBC mode with the given iv, the scheme SCP02 uses to derive its
+// session keys from the card's static keys and sequence counter.
+func TripleDESCBCEncrypt(key, data, iv []byte) ([]byte, error) {
+	block, err := des.NewTripleDESCipher(expandDESKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+
+	return out, nil
+}
+
+// DESEncryptBlock single-DES-encrypts one 8-byte block under the first 8
+// bytes of key, the scheme GPC's retail-MAC chaining uses to turn a
+// command's MAC into the ICV for the command that follows it.
+func DESEncryptBlock(key, data []byte) ([]byte, error) {
+	block, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	block.Encrypt(out, data)
+
+	return out, nil
+}
+
+// TripleDESECBEncrypt encrypts data (a multiple of the DES block size) under
+// key using 3DES in ECB mode, each block independently with no chaining -
+// the scheme GlobalPlatform's PUT KEY uses to encrypt key component material
+// under the DEK before sending it to the card.
+func TripleDESECBEncrypt(key, data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%8 != 0 {
+		return nil, errors.New("TripleDESECBEncrypt: data must be a non-zero multiple of the DES block size")
+	}
+
+	block, err := des.NewTripleDESCipher(expandDESKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	for offset := 0; offset < len(data); offset += 8 {
+		block.Encrypt(out[offset:offset+8], data[offset:offset+8])
+	}
+
+	return out, nil
+}
+
+// expandDESKey expands a 16-byte double-length DES key into the 24-byte form
+// crypto/des expects (K1 || K2 || K1); GlobalPlatform keys are provisioned as
+// 16 bytes.
+func expandDESKey(key []byte) []byte {
+	if len(key) == 24 {
+		return key
+	}
+
+	expanded := make([]byte, 24)
+	copy(expanded, key)
+	copy(expanded[16:], key[:8])
+
+	return expanded
+}
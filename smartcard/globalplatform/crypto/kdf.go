@@ -0,0 +1,36 @@
+package crypto
+
+import "encoding/binary"
+
+// CounterModeKDF derives outputBits of keying material from key using the
+// AES-CMAC-based counter-mode KDF defined in NIST SP 800-108, the form
+// GlobalPlatform's SCP03 uses to derive S-ENC/S-MAC/S-DEK session keys and
+// the host/card authentication cryptograms.
+//
+// label identifies the purpose of the derived value (GPC Amendment D's
+// "derivation constant"), context is the concatenation of the host and card
+// challenges, and outputBits is the length of the derived value in bits.
+func CounterModeKDF(key []byte, label byte, context []byte, outputBits int) ([]byte, error) {
+	l := make([]byte, 2)
+	binary.BigEndian.PutUint16(l, uint16(outputBits))
+
+	var out []byte
+	for i := uint8(1); len(out)*8 < outputBits; i++ {
+		data := make([]byte, 0, 11+1+1+2+1+len(context))
+		data = append(data, make([]byte, 11)...) // fixed label prefix, unused by GlobalPlatform
+		data = append(data, label)
+		data = append(data, 0x00) // separation indicator
+		data = append(data, l...)
+		data = append(data, i)
+		data = append(data, context...)
+
+		block, err := AESCMAC(key, data)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, block...)
+	}
+
+	return out[:outputBits/8], nil
+}
@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCounterModeKDFIsDeterministicAndLabelDependent(t *testing.T) {
+	key := mustHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	context := mustHex(t, "0102030405060708")
+
+	a, err := CounterModeKDF(key, 0x04, context, 128)
+	if err != nil {
+		t.Fatalf("CounterModeKDF failed: %v", err)
+	}
+	again, err := CounterModeKDF(key, 0x04, context, 128)
+	if err != nil {
+		t.Fatalf("CounterModeKDF failed: %v", err)
+	}
+	if !bytes.Equal(a, again) {
+		t.Error("CounterModeKDF must be deterministic for the same inputs")
+	}
+
+	b, err := CounterModeKDF(key, 0x06, context, 128)
+	if err != nil {
+		t.Fatalf("CounterModeKDF failed: %v", err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("CounterModeKDF outputs for different labels must differ")
+	}
+	if len(b) != 16 {
+		t.Errorf("len(b) = %d, want 16 for a 128-bit output", len(b))
+	}
+
+	c, err := CounterModeKDF(key, 0x04, context, 64)
+	if err != nil {
+		t.Fatalf("CounterModeKDF failed: %v", err)
+	}
+	if len(c) != 8 {
+		t.Errorf("len(c) = %d, want 8 for a 64-bit output", len(c))
+	}
+	if !bytes.Equal(c, a[:8]) {
+		t.Error("CounterModeKDF must produce the same leading bytes regardless of requested output length")
+	}
+}
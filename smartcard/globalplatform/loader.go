@@ -0,0 +1,118 @@
+package globalplatform
+
+import (
+	"fmt"
+
+	"github.com/status-im/status-go/smartcard/apdu"
+)
+
+// maxLoadBlockSize is the largest LOAD command data field accepted by the
+// great majority of cards (the short APDU length limit minus headroom for
+// the secure channel MAC appended by the current session).
+const maxLoadBlockSize = 247
+
+// Channel sends a single APDU command to the card and returns its response.
+type Channel interface {
+	Send(cmd *apdu.Command) (*apdu.Response, error)
+}
+
+// SecureChannelAdapter wraps ch so every command sent through it is first
+// protected by sc's negotiated security level (and, symmetrically, every
+// response is unwrapped), letting CAPLoader drive LOAD/INSTALL over an
+// authenticated channel without knowing about SecureChannel itself.
+func SecureChannelAdapter(ch Channel, sc SecureChannel) Channel {
+	return &secureChannelAdapter{channel: ch, sc: sc}
+}
+
+type secureChannelAdapter struct {
+	channel Channel
+	sc      SecureChannel
+}
+
+func (a *secureChannelAdapter) Send(cmd *apdu.Command) (*apdu.Response, error) {
+	wrapped, err := a.sc.Wrap(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.channel.Send(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.sc.Unwrap(resp)
+}
+
+// CAPLoader drives the INSTALL [for load] / LOAD / INSTALL [for install]
+// command sequence that provisions a CAP file's package and applets onto
+// the card. channel is responsible for any required authentication: wrap it
+// with SecureChannelAdapter to MAC (and optionally encrypt) these commands
+// over an open SecureChannel.
+type CAPLoader struct {
+	channel Channel
+}
+
+// NewCAPLoader returns a CAPLoader that sends its commands through channel.
+func NewCAPLoader(channel Channel) *CAPLoader {
+	return &CAPLoader{channel: channel}
+}
+
+// Load installs cap's package onto the security domain sdAID, chaining as
+// many LOAD commands as needed to transfer its Load File Data Block.
+func (l *CAPLoader) Load(cap *CAPFile, sdAID []byte) error {
+	pkgAID, err := cap.PackageAID()
+	if err != nil {
+		return err
+	}
+
+	if err := l.send(NewCommandInstallForLoad(pkgAID, sdAID)); err != nil {
+		return fmt.Errorf("INSTALL [for load] failed: %v", err)
+	}
+
+	block := cap.LoadFileDataBlock()
+
+	blocksNeeded := (len(block) + maxLoadBlockSize - 1) / maxLoadBlockSize
+	if blocksNeeded > 256 {
+		return fmt.Errorf("load file data block needs %d blocks, which overflows LOAD's 1-byte block number", blocksNeeded)
+	}
+
+	offset := 0
+	for blockNum := uint8(0); offset < len(block); blockNum++ {
+		end := offset + maxLoadBlockSize
+		last := end >= len(block)
+		if last {
+			end = len(block)
+		}
+
+		if err := l.send(NewCommandLoad(blockNum, last, block[offset:end])); err != nil {
+			return fmt.Errorf("LOAD block %d failed: %v", blockNum, err)
+		}
+
+		offset = end
+	}
+
+	return nil
+}
+
+// Install instantiates the applet appletAID, from the package pkgAID
+// already transferred via Load, as instanceAID with the given parameters.
+func (l *CAPLoader) Install(pkgAID, appletAID, instanceAID, params []byte) error {
+	if err := l.send(NewCommandInstallForInstall(pkgAID, appletAID, instanceAID, params)); err != nil {
+		return fmt.Errorf("INSTALL [for install] failed: %v", err)
+	}
+
+	return nil
+}
+
+func (l *CAPLoader) send(cmd *apdu.Command) error {
+	resp, err := l.channel.Send(cmd)
+	if err != nil {
+		return err
+	}
+
+	if resp.Sw() != SwOK {
+		return fmt.Errorf("unexpected status word %x", resp.Sw())
+	}
+
+	return nil
+}
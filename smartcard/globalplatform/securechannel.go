@@ -0,0 +1,469 @@
+package globalplatform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/status-im/status-go/smartcard/apdu"
+	"github.com/status-im/status-go/smartcard/globalplatform/crypto"
+)
+
+// StaticKeys are the long-term ENC/MAC/DEK keys provisioned on the card,
+// used to derive per-session keys (or, for SCP01, used directly) when
+// opening a secure channel.
+type StaticKeys struct {
+	Enc []byte
+	Mac []byte
+	Dek []byte
+}
+
+// SecurityLevel controls which protections, beyond the mandatory C-MAC, are
+// applied to a secure channel once it is open.
+type SecurityLevel uint8
+
+const (
+	SecurityLevelCMAC        SecurityLevel = 0x01
+	SecurityLevelCDecryption SecurityLevel = 0x02
+	SecurityLevelRMAC        SecurityLevel = 0x10
+	SecurityLevelREncryption SecurityLevel = 0x20
+)
+
+func (l SecurityLevel) has(flag SecurityLevel) bool {
+	return l&flag == flag
+}
+
+// SecureChannel authenticates a GlobalPlatform session against the card and
+// protects the commands exchanged over it, independently of which Secure
+// Channel Protocol version (SCP01/02/03) the card negotiated.
+type SecureChannel interface {
+	// Open derives the session keys from cardResponse (the INITIALIZE
+	// UPDATE response) and hostChallenge, verifies the card's cryptogram,
+	// and returns the EXTERNAL AUTHENTICATE command that completes the
+	// handshake.
+	Open(cardResponse, hostChallenge []byte) (*apdu.Command, error)
+	// Wrap applies this channel's negotiated security level to cmd.
+	Wrap(cmd *apdu.Command) (*apdu.Command, error)
+	// Unwrap verifies and removes this channel's response-side protections
+	// (R-MAC/R-ENCRYPTION) from resp, or returns it unchanged if neither was
+	// negotiated.
+	Unwrap(resp *apdu.Response) (*apdu.Response, error)
+	// DEK returns the key PUT KEY payloads must be encrypted under: the
+	// static DEK for SCP01 and SCP03, which don't derive a per-session key
+	// encryption key, or the derived session DEK for SCP02.
+	DEK() []byte
+}
+
+// initUpdateInfo is the parsed form of an INITIALIZE UPDATE response, common
+// to all three secure channel protocols modulo the optional i parameter
+// SCP03 carries.
+type initUpdateInfo struct {
+	keyVersion     uint8
+	scpID          uint8
+	i              uint8
+	cardChallenge  []byte
+	cardCryptogram []byte
+}
+
+func parseInitializeUpdateResponse(data []byte) (*initUpdateInfo, error) {
+	if len(data) < 28 {
+		return nil, errors.New("INITIALIZE UPDATE response is too short")
+	}
+
+	info := &initUpdateInfo{
+		keyVersion: data[10],
+		scpID:      data[11],
+	}
+
+	offset := 12
+	if info.scpID == 0x03 {
+		if len(data) < 29 {
+			return nil, errors.New("SCP03 INITIALIZE UPDATE response is too short")
+		}
+		info.i = data[12]
+		offset = 13
+	}
+
+	info.cardChallenge = data[offset : offset+8]
+	info.cardCryptogram = data[offset+8 : offset+16]
+
+	return info, nil
+}
+
+// OpenSecureChannel inspects the Secure Channel Protocol identifier carried
+// by cardResponse (the INITIALIZE UPDATE response) and returns the
+// SecureChannel implementation matching it.
+func OpenSecureChannel(keys StaticKeys, level SecurityLevel, cardResponse []byte) (SecureChannel, error) {
+	info, err := parseInitializeUpdateResponse(cardResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	switch info.scpID {
+	case 0x01:
+		return &scp01Session{keys: keys, level: level, info: info}, nil
+	case 0x02:
+		return &scp02Session{keys: keys, level: level, info: info}, nil
+	case 0x03:
+		return &scp03Session{keys: keys, level: level, info: info}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secure channel protocol %#x", info.scpID)
+	}
+}
+
+// scp01Session implements SCP01: no session key derivation, cryptograms and
+// the C-MAC are a 3DES retail MAC over the static keys directly.
+type scp01Session struct {
+	keys  StaticKeys
+	level SecurityLevel
+	info  *initUpdateInfo
+
+	icv []byte
+}
+
+func (s *scp01Session) Open(cardResponse, hostChallenge []byte) (*apdu.Command, error) {
+	if s.level.has(SecurityLevelRMAC) || s.level.has(SecurityLevelREncryption) {
+		return nil, errors.New("SCP01 does not support R-MAC or R-ENCRYPTION")
+	}
+	if s.level.has(SecurityLevelCDecryption) {
+		return nil, errors.New("SCP01 session does not support C-DECRYPTION")
+	}
+
+	expected, err := retailMACCryptogram(s.keys.Enc, hostChallenge, s.info.cardChallenge)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, s.info.cardCryptogram) {
+		return nil, errors.New("invalid card cryptogram")
+	}
+
+	hostCryptogram, err := retailMACCryptogram(s.keys.Enc, s.info.cardChallenge, hostChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	s.icv = crypto.NullBytes8
+
+	return NewCommandExternalAuthenticate(hostCryptogram), nil
+}
+
+func (s *scp01Session) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
+	return wrapWithRetailMAC(cmd, s.keys.Mac, &s.icv)
+}
+
+// Unwrap is a no-op: Open already rejected R-MAC/R-ENCRYPTION, the only
+// response-side protections this interface models, so SCP01 never has
+// anything to undo.
+func (s *scp01Session) Unwrap(resp *apdu.Response) (*apdu.Response, error) {
+	return resp, nil
+}
+
+func (s *scp01Session) DEK() []byte {
+	return s.keys.Dek
+}
+
+// scp02Session implements SCP02: session keys are derived from the static
+// keys and a sequence counter (the first two bytes of the card challenge)
+// via 3DES CBC, with cryptograms and the C-MAC computed the same way SCP01
+// does but using the derived S-ENC/S-MAC keys.
+type scp02Session struct {
+	keys  StaticKeys
+	level SecurityLevel
+	info  *initUpdateInfo
+
+	sessionEnc  []byte
+	sessionMac  []byte
+	sessionDek  []byte
+	sessionRMac []byte
+	icv         []byte
+	rmacICV     []byte
+}
+
+var (
+	scp02DerivationSENC = []byte{0x01, 0x82}
+	scp02DerivationCMAC = []byte{0x01, 0x01}
+	scp02DerivationDEK  = []byte{0x01, 0x81}
+	scp02DerivationRMAC = []byte{0x01, 0x02}
+)
+
+func deriveSCP02Key(staticKey, constant []byte, counter uint16) ([]byte, error) {
+	data := make([]byte, 16)
+	copy(data[0:2], constant)
+	binary.BigEndian.PutUint16(data[2:4], counter)
+
+	return crypto.TripleDESCBCEncrypt(staticKey, data, crypto.NullBytes8)
+}
+
+func (s *scp02Session) Open(cardResponse, hostChallenge []byte) (*apdu.Command, error) {
+	counter := binary.BigEndian.Uint16(s.info.cardChallenge[:2])
+
+	var err error
+	s.sessionEnc, err = deriveSCP02Key(s.keys.Enc, scp02DerivationSENC, counter)
+	if err != nil {
+		return nil, err
+	}
+	s.sessionMac, err = deriveSCP02Key(s.keys.Mac, scp02DerivationCMAC, counter)
+	if err != nil {
+		return nil, err
+	}
+	s.sessionDek, err = deriveSCP02Key(s.keys.Dek, scp02DerivationDEK, counter)
+	if err != nil {
+		return nil, err
+	}
+	if s.level.has(SecurityLevelRMAC) {
+		s.sessionRMac, err = deriveSCP02Key(s.keys.Mac, scp02DerivationRMAC, counter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.level.has(SecurityLevelREncryption) {
+		return nil, errors.New("SCP02 does not support R-ENCRYPTION")
+	}
+	if s.level.has(SecurityLevelCDecryption) {
+		return nil, errors.New("SCP02 session does not support C-DECRYPTION")
+	}
+
+	expected, err := retailMACCryptogram(s.sessionEnc, hostChallenge, s.info.cardChallenge)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, s.info.cardCryptogram) {
+		return nil, errors.New("invalid card cryptogram")
+	}
+
+	hostCryptogram, err := retailMACCryptogram(s.sessionEnc, s.info.cardChallenge, hostChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	s.icv = crypto.NullBytes8
+	s.rmacICV = crypto.NullBytes8
+
+	return NewCommandExternalAuthenticate(hostCryptogram), nil
+}
+
+func (s *scp02Session) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
+	return wrapWithRetailMAC(cmd, s.sessionMac, &s.icv)
+}
+
+// Unwrap verifies and strips the trailing 8-byte R-MAC GlobalPlatform
+// appends to resp.Data when R-MAC is negotiated, chaining from its own ICV
+// independently of the command C-MAC chain.
+func (s *scp02Session) Unwrap(resp *apdu.Response) (*apdu.Response, error) {
+	if !s.level.has(SecurityLevelRMAC) {
+		return resp, nil
+	}
+
+	if len(resp.Data) < 8 {
+		return nil, errors.New("R-MAC response is too short")
+	}
+
+	data := resp.Data[:len(resp.Data)-8]
+	mac := resp.Data[len(resp.Data)-8:]
+
+	macInput := crypto.AppendDESPadding(append(append([]byte{}, data...), resp.Sw1, resp.Sw2))
+	expected, err := crypto.Mac3DES(s.sessionRMac, macInput, s.rmacICV)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, mac) {
+		return nil, errors.New("invalid R-MAC")
+	}
+	s.rmacICV = expected
+
+	return &apdu.Response{Data: data, Sw1: resp.Sw1, Sw2: resp.Sw2}, nil
+}
+
+func (s *scp02Session) DEK() []byte {
+	return s.sessionDek
+}
+
+// scp03Session implements SCP03: session keys and cryptograms are derived
+// with the AES-CMAC counter-mode KDF from NIST SP 800-108, and the C-MAC is
+// AES-CMAC chained across the session rather than a 3DES retail MAC.
+// C-DECRYPTION additionally AES-CBC encrypts the command data before MACing
+// it, with the IV derived from a separate session encryption counter (see
+// counterIV) rather than from the MAC chaining ICV.
+type scp03Session struct {
+	keys  StaticKeys
+	level SecurityLevel
+	info  *initUpdateInfo
+
+	sessionEnc  []byte
+	sessionMac  []byte
+	sessionRMac []byte
+	icv         []byte
+	counter     uint64
+}
+
+const (
+	scp03DeriveCardCryptogram = byte(0x00)
+	scp03DeriveHostCryptogram = byte(0x01)
+	scp03DeriveSENC           = byte(0x04)
+	scp03DeriveSMAC           = byte(0x06)
+	scp03DeriveSRMAC          = byte(0x07)
+)
+
+func (s *scp03Session) Open(cardResponse, hostChallenge []byte) (*apdu.Command, error) {
+	if s.level.has(SecurityLevelREncryption) && !s.level.has(SecurityLevelRMAC) {
+		return nil, errors.New("SCP03 R-ENCRYPTION requires R-MAC")
+	}
+
+	context :=append(append([]byte{}, hostChallenge...), s.info.cardChallenge...)
+
+	var err error
+	s.sessionEnc, err = crypto.CounterModeKDF(s.keys.Enc, scp03DeriveSENC, context, 128)
+	if err != nil {
+		return nil, err
+	}
+	s.sessionMac, err = crypto.CounterModeKDF(s.keys.Mac, scp03DeriveSMAC, context, 128)
+	if err != nil {
+		return nil, err
+	}
+	if s.level.has(SecurityLevelRMAC) {
+		s.sessionRMac, err = crypto.CounterModeKDF(s.keys.Mac, scp03DeriveSRMAC, context, 128)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expected, err := crypto.CounterModeKDF(s.sessionMac, scp03DeriveCardCryptogram, context, 64)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, s.info.cardCryptogram) {
+		return nil, errors.New("invalid card cryptogram")
+	}
+
+	hostCryptogram, err := crypto.CounterModeKDF(s.sessionMac, scp03DeriveHostCryptogram, context, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	s.icv = make([]byte, 16)
+
+	return NewCommandExternalAuthenticate(hostCryptogram), nil
+}
+
+func (s *scp03Session) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
+	data := cmd.Data
+	if s.level.has(SecurityLevelCDecryption) && len(data) > 0 {
+		encrypted, err := s.encryptData(data)
+		if err != nil {
+			return nil, err
+		}
+		data = encrypted
+	}
+
+	header := []byte{cmd.Cla | ClaMac, cmd.Ins, cmd.P1, cmd.P2, byte(len(data) + 8)}
+	mac, err := crypto.AESCMAC(s.sessionMac, append(append(append([]byte{}, s.icv...), header...), data...))
+	if err != nil {
+		return nil, err
+	}
+	s.icv = mac
+
+	return apdu.NewCommand(cmd.Cla|ClaMac, cmd.Ins, cmd.P1, cmd.P2, append(append([]byte{}, data...), mac[:8]...)), nil
+}
+
+// encryptData AES-CBC encrypts cmd data under the session ENC key, with the
+// IV derived by AES-ECB-encrypting the session's command counter as required
+// by GPC Amendment D section 6.2.6.
+func (s *scp03Session) encryptData(data []byte) ([]byte, error) {
+	iv, err := s.counterIV()
+	if err != nil {
+		return nil, err
+	}
+
+	padded := crypto.Pad(data, 16)
+
+	return crypto.AESCBCEncrypt(s.sessionEnc, padded, iv)
+}
+
+// counterIV advances and AES-ECB-encrypts the session command counter, the
+// IV GPC Amendment D section 6.2.6/6.2.7 uses for both C-DECRYPTION and
+// R-ENCRYPTION.
+func (s *scp03Session) counterIV() ([]byte, error) {
+	s.counter++
+	counterBlock := make([]byte, 16)
+	binary.BigEndian.PutUint64(counterBlock[8:], s.counter)
+
+	return crypto.AESECBEncryptBlock(s.sessionEnc, counterBlock)
+}
+
+// Unwrap verifies the trailing AES-CMAC R-MAC GlobalPlatform appends to
+// resp.Data when R-MAC is negotiated, then AES-CBC decrypts the remaining
+// data when R-ENCRYPTION is also negotiated.
+func (s *scp03Session) Unwrap(resp *apdu.Response) (*apdu.Response, error) {
+	if !s.level.has(SecurityLevelRMAC) {
+		return resp, nil
+	}
+
+	if len(resp.Data) < 8 {
+		return nil, errors.New("R-MAC response is too short")
+	}
+
+	data := resp.Data[:len(resp.Data)-8]
+	mac := resp.Data[len(resp.Data)-8:]
+
+	trailer := []byte{resp.Sw1, resp.Sw2}
+	macInput := append(append(append([]byte{}, s.icv...), data...), trailer...)
+	expected, err := crypto.AESCMAC(s.sessionRMac, macInput)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected[:8], mac) {
+		return nil, errors.New("invalid R-MAC")
+	}
+
+	if s.level.has(SecurityLevelREncryption) && len(data) > 0 {
+		iv, err := s.counterIV()
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted, err := crypto.AESCBCDecrypt(s.sessionEnc, data, iv)
+		if err != nil {
+			return nil, err
+		}
+		data = decrypted
+	}
+
+	return &apdu.Response{Data: data, Sw1: resp.Sw1, Sw2: resp.Sw2}, nil
+}
+
+func (s *scp03Session) DEK() []byte {
+	return s.keys.Dek
+}
+
+// retailMACCryptogram computes the 3DES retail MAC GlobalPlatform uses for
+// SCP01/02 cryptograms: challenge||counterChallenge, DES-padded, MACed under
+// key with a null ICV.
+func retailMACCryptogram(key, challenge, counterChallenge []byte) ([]byte, error) {
+	data := append(append([]byte{}, challenge...), counterChallenge...)
+	data = crypto.AppendDESPadding(data)
+
+	return crypto.Mac3DES(key, data, crypto.NullBytes8)
+}
+
+// wrapWithRetailMAC appends a 3DES retail C-MAC, chained from icv, to cmd's
+// data and marks the command as MACed by switching its CLA to ClaMac. Per
+// GPC's retail-MAC chaining, icv is then advanced to the single-DES
+// encryption of this MAC under macKey, not the raw MAC itself, so it is
+// ready to seed the next command in the chain.
+func wrapWithRetailMAC(cmd *apdu.Command, macKey []byte, icv *[]byte) (*apdu.Command, error) {
+	header := []byte{cmd.Cla | ClaMac, cmd.Ins, cmd.P1, cmd.P2, byte(len(cmd.Data) + 8)}
+	data := crypto.AppendDESPadding(append(header, cmd.Data...))
+
+	mac, err := crypto.Mac3DES(macKey, data, *icv)
+	if err != nil {
+		return nil, err
+	}
+
+	*icv, err = crypto.DESEncryptBlock(macKey, mac)
+	if err != nil {
+		return nil, err
+	}
+
+	return apdu.NewCommand(cmd.Cla|ClaMac, cmd.Ins, cmd.P1, cmd.P2, append(append([]byte{}, cmd.Data...), mac...)), nil
+}